@@ -0,0 +1,166 @@
+/*
+Package config defines the schema for the containersvcmon -config file,
+which lets a single binary supervise several containers instead of just the
+one named on the command line.
+
+Copyright (c) 2017 Nutanix Inc. All rights reserved.
+
+Author: rohith.subramanyam@nutanix.com
+*/
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Duration is a time.Duration that unmarshals from the same strings
+// time.ParseDuration accepts (e.g. "30s", "1m30s"), so a config file can
+// write durations the same way they are given on the command line instead
+// of spelling out raw nanosecond integers.
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %s", s, err)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %s", s, err)
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Entry describes one container to supervise. Its fields mirror
+// containersvcmon's command-line flags; apart from the required image_path,
+// image and container_name, a zero-valued field means "use the command-line
+// default" so an entry only needs to specify what it overrides.
+type Entry struct {
+	ImagePath string `yaml:"image_path" json:"image_path"`
+	Image     string `yaml:"image" json:"image"`
+
+	ContainerName string   `yaml:"container_name" json:"container_name"`
+	Ports         []string `yaml:"ports,omitempty" json:"ports,omitempty"`
+	VolumeDriver  string   `yaml:"volume_driver,omitempty" json:"volume_driver,omitempty"`
+	Volumes       []string `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	Env           []string `yaml:"env,omitempty" json:"env,omitempty"`
+	Labels        []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	VolumeOpts    []string `yaml:"volume_opts,omitempty" json:"volume_opts,omitempty"`
+	RestartPolicy string   `yaml:"restart_policy,omitempty" json:"restart_policy,omitempty"`
+
+	Background  *bool `yaml:"background,omitempty" json:"background,omitempty"`
+	AutoRemove  *bool `yaml:"auto_remove,omitempty" json:"auto_remove,omitempty"`
+	Log         *bool `yaml:"log,omitempty" json:"log,omitempty"`
+	OpenStdin   *bool `yaml:"interactive,omitempty" json:"interactive,omitempty"`
+	Tty         *bool `yaml:"tty,omitempty" json:"tty,omitempty"`
+	OneInstance *bool `yaml:"one_instance,omitempty" json:"one_instance,omitempty"`
+
+	HealthCmd         string   `yaml:"health_cmd,omitempty" json:"health_cmd,omitempty"`
+	HealthInterval    Duration `yaml:"health_interval,omitempty" json:"health_interval,omitempty"`
+	HealthTimeout     Duration `yaml:"health_timeout,omitempty" json:"health_timeout,omitempty"`
+	HealthRetries     int      `yaml:"health_retries,omitempty" json:"health_retries,omitempty"`
+	HealthStartPeriod Duration `yaml:"health_start_period,omitempty" json:"health_start_period,omitempty"`
+
+	RestartMinDelay    Duration `yaml:"restart_min_delay,omitempty" json:"restart_min_delay,omitempty"`
+	RestartMaxDelay    Duration `yaml:"restart_max_delay,omitempty" json:"restart_max_delay,omitempty"`
+	RestartMultiplier  float64  `yaml:"restart_multiplier,omitempty" json:"restart_multiplier,omitempty"`
+	RestartResetAfter  Duration `yaml:"restart_reset_after,omitempty" json:"restart_reset_after,omitempty"`
+	CrashLoopThreshold int      `yaml:"crash_loop_threshold,omitempty" json:"crash_loop_threshold,omitempty"`
+	CrashLoopWindow    Duration `yaml:"crash_loop_window,omitempty" json:"crash_loop_window,omitempty"`
+}
+
+// File is the top-level schema of a -config file: a list of container
+// entries to supervise.
+type File struct {
+	Containers []Entry `yaml:"containers" json:"containers"`
+}
+
+// Validate checks that e has its mandatory fields set and that its restart
+// policy, if given, is one containersvcmon understands. The ports, volumes,
+// env, labels and volume-opts fields are validated by containersvcmon
+// itself using the same helpers it validates the equivalent command-line
+// flags with, so a malformed entry is rejected the same way a bad flag
+// would be; containersvcmon runs that validation for every entry before
+// starting any of them, so a bad entry is rejected up front rather than
+// mid-startup. The health check and restart backoff fields are not
+// validated.
+func (e *Entry) Validate() error {
+	if e.ImagePath == "" {
+		return fmt.Errorf("entry %q: image_path is required", e.Image)
+	}
+	if e.Image == "" {
+		return fmt.Errorf("entry with image_path %q: image is required",
+			e.ImagePath)
+	}
+	if e.ContainerName == "" {
+		return fmt.Errorf("entry %q: container_name is required", e.Image)
+	}
+
+	switch e.RestartPolicy {
+	case "", "no", "on-failure", "unless-stopped", "always":
+	default:
+		return fmt.Errorf("entry %q: invalid restart_policy %q", e.Image,
+			e.RestartPolicy)
+	}
+
+	return nil
+}
+
+// Load reads and parses a -config file. Files named *.json are parsed as
+// JSON; everything else is parsed as YAML.
+func Load(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", path, err)
+	}
+
+	var file File
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", path, err)
+	}
+
+	for i := range file.Containers {
+		if err := file.Containers[i].Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	return &file, nil
+}
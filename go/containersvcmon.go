@@ -9,16 +9,23 @@ Author: rohith.subramanyam@nutanix.com
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/golang/glog"
 
+	"config"
 	"containersvc"
 )
 
@@ -48,6 +55,9 @@ var (
 	volDriver = flag.String("volume-driver", "",
 		"Optional volume driver for the container")
 	vols    arrayFlags
+	envOpts arrayFlags
+	labels  arrayFlags
+	volOpts arrayFlags
 	bckgrnd = flag.Bool("background", false,
 		"Run the container in the background")
 	restartPolicy containersvc.RestartPolicyEnum = containersvc.No
@@ -60,9 +70,348 @@ var (
 		"including stdin if it is not closed.")
 	oneCtr = flag.Bool("one-instance", true, "Only one container instance"+
 		" of the image can be running.")
+	healthCmd = flag.String("health-cmd", "", "Command to run inside the "+
+		"container to check its health, in exec form, e.g. "+
+		"\"curl -f http://localhost/health\"")
+	healthInterval = flag.Duration("health-interval", 30*time.Second,
+		"Interval between health checks")
+	healthTimeout = flag.Duration("health-timeout", 5*time.Second,
+		"Timeout for a single health check invocation")
+	healthRetries = flag.Int("health-retries", 3, "Number of consecutive "+
+		"health check failures before the container is considered "+
+		"unhealthy")
+	healthStartPeriod = flag.Duration("health-start-period", 0, "Grace "+
+		"period after container start during which health check "+
+		"failures do not count towards -health-retries")
+	restartMinDelay = flag.Duration("restart-min-delay", 2*time.Second,
+		"Minimum delay before restarting a failed container")
+	restartMaxDelay = flag.Duration("restart-max-delay", 60*time.Second,
+		"Maximum delay before restarting a failed container")
+	restartMultiplier = flag.Float64("restart-multiplier", 2.0, "Multiplier"+
+		" applied to the restart delay after each consecutive restart")
+	restartResetAfter = flag.Duration("restart-reset-after", 60*time.Second,
+		"Reset the restart delay back to -restart-min-delay if the "+
+			"container stayed up at least this long")
+	crashLoopThreshold = flag.Int("crash-loop-threshold", 5, "Number of "+
+		"restarts within -crash-loop-window after which monitoring is "+
+		"aborted as a crash loop")
+	crashLoopWindow = flag.Duration("crash-loop-window", 60*time.Second,
+		"Sliding window used to detect crash loops, see "+
+			"-crash-loop-threshold")
+	controlAddr = flag.String("control-addr", "", "Optional address, e.g. "+
+		"127.0.0.1:6771, to serve an HTTP status/control API on. The "+
+		"API is disabled when this is empty.")
+	configPath = flag.String("config", "", "Path to a YAML or JSON file "+
+		"listing container entries to supervise. When set, one monitoring "+
+		"loop per entry is started and the positional arguments are not "+
+		"used; command-line flags act as defaults entries can override. "+
+		"Reloaded on SIGHUP.")
+	stopTimeout = flag.Duration("stop-timeout", 10*time.Second, "How long "+
+		"to wait for the container to exit on its own after it is sent "+
+		"SIGTERM before escalating to a hard stop")
+	forwardSignals = flag.String("forward-signals", "HUP,USR1,USR2",
+		"Comma-separated list of additional signals to relay into the "+
+			"container, besides the SIGTERM sent on shutdown")
 )
 
-var stopMonitoring bool // Used to stop the runLoop.
+// stopMonitoring, once set, tells runLoop not to restart the container after
+// it next exits. It is written from the signal handler and the control API's
+// /stop handler and read by runLoop, so access goes through
+// setStopMonitoring/getStopMonitoring rather than a bare bool.
+var (
+	stopMonitoringMu sync.Mutex
+	stopMonitoring   bool
+)
+
+// setStopMonitoring sets the stopMonitoring flag.
+func setStopMonitoring() {
+	stopMonitoringMu.Lock()
+	defer stopMonitoringMu.Unlock()
+
+	stopMonitoring = true
+}
+
+// getStopMonitoring returns whether stopMonitoring has been set.
+func getStopMonitoring() bool {
+	stopMonitoringMu.Lock()
+	defer stopMonitoringMu.Unlock()
+
+	return stopMonitoring
+}
+
+// Health states the monitor tracks for the container's health check.
+const (
+	healthStateStarting  = "starting"
+	healthStateHealthy   = "healthy"
+	healthStateUnhealthy = "unhealthy"
+)
+
+var (
+	healthMu    sync.Mutex
+	healthState = healthStateStarting
+)
+
+// setHealthState updates the tracked health state, logging the transition if
+// it changed.
+func setHealthState(state string) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	if healthState != state {
+		glog.Infof("Health state transition: %s -> %s", healthState, state)
+		healthState = state
+	}
+}
+
+// getHealthState returns the currently tracked health state.
+func getHealthState() string {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+
+	return healthState
+}
+
+// monitorStatus is the state reported by the control API's /status endpoint.
+type monitorStatus struct {
+	Image         string    `json:"image"`
+	ContainerName string    `json:"container_name"`
+	State         string    `json:"state"`
+	StartedAt     time.Time `json:"started_at"`
+	RestartCount  int       `json:"restart_count"`
+	LastExitCode  int       `json:"last_exit_code"`
+	Health        string    `json:"health"`
+}
+
+var (
+	statusMu sync.Mutex
+	status   monitorStatus
+)
+
+// updateStatus applies fn to the shared monitor status under lock. runLoop
+// calls this to keep the control API's view of the world current; the health
+// state is tracked separately by setHealthState and merged in by
+// snapshotStatus.
+func updateStatus(fn func(*monitorStatus)) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	fn(&status)
+}
+
+// snapshotStatus returns a copy of the current monitor status, with the
+// latest health state merged in.
+func snapshotStatus() monitorStatus {
+	statusMu.Lock()
+	s := status
+	statusMu.Unlock()
+
+	s.Health = getHealthState()
+
+	return s
+}
+
+// exitCode extracts the process exit code from the error returned by
+// containersvc.Start, or -1 if it cannot be determined.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// restartBackoff tracks the delay to use between container restarts,
+// doubling it (up to a cap) on each consecutive failure and resetting it
+// once the container has stayed up long enough to no longer be considered
+// flapping.
+type restartBackoff struct {
+	minDelay   time.Duration
+	maxDelay   time.Duration
+	multiplier float64
+	resetAfter time.Duration
+
+	delay time.Duration
+}
+
+// newRestartBackoff returns a restartBackoff starting at minDelay.
+func newRestartBackoff(minDelay, maxDelay time.Duration, multiplier float64,
+	resetAfter time.Duration) *restartBackoff {
+
+	return &restartBackoff{
+		minDelay:   minDelay,
+		maxDelay:   maxDelay,
+		multiplier: multiplier,
+		resetAfter: resetAfter,
+		delay:      minDelay,
+	}
+}
+
+// next returns the delay to use before the next restart given how long the
+// container that just exited had been up, and advances the backoff state for
+// the following call.
+func (b *restartBackoff) next(uptime time.Duration) time.Duration {
+	if uptime >= b.resetAfter {
+		b.delay = b.minDelay
+		return b.delay
+	}
+
+	delay := b.delay
+
+	b.delay = time.Duration(float64(b.delay) * b.multiplier)
+	if b.delay > b.maxDelay {
+		b.delay = b.maxDelay
+	}
+
+	return delay
+}
+
+// pruneRestarts drops restart timestamps older than window from the front of
+// times, which must be sorted in ascending order.
+func pruneRestarts(times []time.Time, window time.Duration) []time.Time {
+	cutoff := time.Now().Add(-window)
+
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+
+	return times[i:]
+}
+
+// parseKeyValueOpts parses a list of "KEY=VALUE" strings into a map, as used
+// by the -env and -label flags. It returns an error if an entry is missing
+// "=" or has an empty key.
+func parseKeyValueOpts(opts []string) (map[string]string, error) {
+	result := make(map[string]string, len(opts))
+
+	for _, opt := range opts {
+		eqIdx := strings.Index(opt, "=")
+		if eqIdx == -1 {
+			return nil, fmt.Errorf("invalid key=value option, expected "+
+				"KEY=VALUE: %q", opt)
+		}
+
+		key := opt[:eqIdx]
+		if key == "" {
+			return nil, fmt.Errorf("empty key in option: %q", opt)
+		}
+
+		result[key] = opt[eqIdx+1:]
+	}
+
+	return result, nil
+}
+
+// volOptNames are the recognized option values in the third, colon-separated
+// field of a -volume entry: the SELinux relabeling suffixes (:z, :Z) and the
+// bind propagation/mode suffixes Docker also accepts in that slot.
+var volOptNames = map[string]bool{
+	"z":        true,
+	"Z":        true,
+	"ro":       true,
+	"rw":       true,
+	"rshared":  true,
+	"rslave":   true,
+	"rprivate": true,
+}
+
+// parseVolume parses a single -volume entry in the format
+// volume_name/host_path:container_path[:option[,option...]] into a
+// containersvc.VolumeMount, validating that any options after the second
+// colon are recognized.
+func parseVolume(vol string) (containersvc.VolumeMount, error) {
+	parts := strings.SplitN(vol, ":", 3)
+	if len(parts) < 2 {
+		return containersvc.VolumeMount{}, fmt.Errorf("invalid volume %q, "+
+			"expected source:target[:options]", vol)
+	}
+
+	mount := containersvc.VolumeMount{Source: parts[0], Target: parts[1]}
+
+	if len(parts) == 3 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if !volOptNames[opt] {
+				return containersvc.VolumeMount{}, fmt.Errorf(
+					"invalid option %q on volume %q", opt, vol)
+			}
+			mount.Options = append(mount.Options, opt)
+		}
+	}
+
+	return mount, nil
+}
+
+// parseVolumes parses every declared -volume entry into a VolumeMount,
+// stopping at the first invalid entry.
+func parseVolumes(vols []string) ([]containersvc.VolumeMount, error) {
+	mounts := make([]containersvc.VolumeMount, 0, len(vols))
+
+	for _, vol := range vols {
+		mount, err := parseVolume(vol)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, mount)
+	}
+
+	return mounts, nil
+}
+
+// volumeNames returns the source name of each declared -volume entry.
+func volumeNames() []string {
+	mounts, err := parseVolumes(vols)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(mounts))
+	for _, mount := range mounts {
+		names = append(names, mount.Source)
+	}
+
+	return names
+}
+
+// parseDriverOpts parses a list of "NAME.KEY=VALUE" strings, as used by the
+// -volume-opt flag, into a map of volume name to its "KEY=VALUE" driver
+// options. It returns an error if an entry is malformed or NAME does not
+// match one of volNames.
+func parseDriverOpts(opts []string, volNames []string) (map[string][]string, error) {
+	declared := make(map[string]bool, len(volNames))
+	for _, name := range volNames {
+		declared[name] = true
+	}
+
+	result := make(map[string][]string)
+
+	for _, opt := range opts {
+		dotIdx := strings.Index(opt, ".")
+		eqIdx := strings.Index(opt, "=")
+		if dotIdx == -1 || eqIdx == -1 || eqIdx < dotIdx {
+			return nil, fmt.Errorf("invalid volume-opt, expected "+
+				"NAME.KEY=VALUE: %q", opt)
+		}
+
+		name := opt[:dotIdx]
+		key := opt[dotIdx+1 : eqIdx]
+		if key == "" {
+			return nil, fmt.Errorf("empty key in volume-opt: %q", opt)
+		}
+		if !declared[name] {
+			return nil, fmt.Errorf("volume-opt %q refers to volume %q "+
+				"which is not declared with a -volume flag", opt, name)
+		}
+
+		result[name] = append(result[name], fmt.Sprintf("%s=%s", key,
+			opt[eqIdx+1:]))
+	}
+
+	return result, nil
+}
 
 // configure builds a containersvc.Config object from the flags and returns it.
 func configure() *containersvc.Config {
@@ -80,13 +429,29 @@ func configure() *containersvc.Config {
 		portMap[hostPort] = ctrPort
 	}
 
-	volsStrArr := []string(vols)
+	// Errors are ignored here since validateCmdLnFlags already rejected
+	// malformed entries before configure is called.
+	volMounts, _ := parseVolumes(vols)
+	env, _ := parseKeyValueOpts(envOpts)
+	lbls, _ := parseKeyValueOpts(labels)
+	volOptsMap, _ := parseDriverOpts(volOpts, volumeNames())
+
+	var hc *containersvc.HealthCheck
+	if *healthCmd != "" {
+		hc = &containersvc.HealthCheck{
+			Cmd:         strings.Fields(*healthCmd),
+			Interval:    *healthInterval,
+			Timeout:     *healthTimeout,
+			Retries:     *healthRetries,
+			StartPeriod: *healthStartPeriod,
+		}
+	}
 
 	cfg := &containersvc.Config{
 		CtrName:       *ctrName,
 		PortMap:       portMap,
 		VolumeDriver:  *volDriver,
-		Volumes:       volsStrArr,
+		Volumes:       volMounts,
 		Background:    *bckgrnd,
 		RestartPolicy: restartPolicy,
 		AutoRemove:    autoRm,
@@ -94,6 +459,10 @@ func configure() *containersvc.Config {
 		OpenStdin:     openStdin,
 		Tty:           tty,
 		OnlyOneContainerInstancePerImage: *oneCtr,
+		HealthCheck:                      hc,
+		Env:                              env,
+		Labels:                           lbls,
+		VolumeDriverOpts:                 volOptsMap,
 	}
 
 	glog.Infof("containersvc config: %s", containersvc.PPrint(cfg, true))
@@ -103,60 +472,842 @@ func configure() *containersvc.Config {
 
 // runLoop is the monitoring loop where the container is started and is
 // restarted if the container is stopped without the stop signals.
-func runLoop(imgPath string, img string) {
+func runLoop(ctx context.Context, imgPath string, img string) {
 	if glog.V(2) {
 		glog.Info("Entering the run loop")
 	}
 
 	cfg := configure()
 
-	for !stopMonitoring {
-		if err := containersvc.Start(imgPath, img, cfg); err != nil {
+	bo := newRestartBackoff(*restartMinDelay, *restartMaxDelay,
+		*restartMultiplier, *restartResetAfter)
+	var restarts []time.Time
+
+	updateStatus(func(s *monitorStatus) {
+		s.Image = img
+		s.ContainerName = *ctrName
+	})
+
+	for !getStopMonitoring() {
+		monitorCtx, monitorCancel := context.WithCancel(ctx)
+		if cfg.HealthCheck != nil {
+			go monitorHealth(monitorCtx, img, *ctrName, cfg.HealthCheck)
+		}
+
+		startedAt := time.Now()
+		updateStatus(func(s *monitorStatus) {
+			s.State = "running"
+			s.StartedAt = startedAt
+		})
+
+		err := containersvc.Start(imgPath, img, cfg)
+		uptime := time.Since(startedAt)
+		monitorCancel()
+
+		updateStatus(func(s *monitorStatus) {
+			s.State = "stopped"
+			s.LastExitCode = exitCode(err)
+		})
+
+		if err != nil {
 			glog.Errorf("Failed to start container of image %s: "+
 				"%s", img, err)
 		}
-		if !stopMonitoring {
-			glog.Infof("Container of image %s exited. Restarting "+
-				"it after 2 seconds...", img)
-			time.Sleep(time.Second * 2)
+
+		if getStopMonitoring() {
+			break
+		}
+
+		restarts = pruneRestarts(append(restarts, time.Now()), *crashLoopWindow)
+		updateStatus(func(s *monitorStatus) {
+			s.RestartCount = len(restarts)
+		})
+		if len(restarts) > *crashLoopThreshold {
+			glog.Fatalf("Container of image %s restarted %d times within "+
+				"%s; treating this as a crash loop and aborting", img,
+				len(restarts), *crashLoopWindow)
 		}
+
+		delay := bo.next(uptime)
+		glog.Infof("Container of image %s exited after %s uptime. "+
+			"Restarting it after %s (restart #%d in the last %s)...",
+			img, uptime, delay, len(restarts), *crashLoopWindow)
+		time.Sleep(delay)
 	}
 	glog.Infof("Stopping monitoring of container of image: %s", img)
 }
 
-// stopSigHandler is the signal handler for signals that could be used to stop
-// the container service: SIGINT, SIGQUIT, SIGTERM.
-// It stops the container and terminates the run loop to gracefully exit.
-// It runs as a goroutine and is waiting on the sigChan channel for the stop
-// signals.
-func stopSigHandler(sigChan chan os.Signal, img string) {
+// monitorHealth periodically runs the configured health check command inside
+// the running container and, once -health-retries consecutive checks have
+// failed, calls containersvc.Stop so runLoop restarts it. Failures during the
+// -health-start-period grace period after container start do not count.
+// It returns once the container is stopped for being unhealthy, the
+// container exits on its own (ctx is cancelled by runLoop), or stopMonitoring
+// is set (ctx is cancelled by main).
+func monitorHealth(ctx context.Context, img, ctrNameVal string,
+	hc *containersvc.HealthCheck) {
+
+	setHealthState(healthStateStarting)
+
+	started := time.Now()
+	failures := 0
+
+	ticker := time.NewTicker(hc.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, hc.Timeout)
+		err := exec.CommandContext(checkCtx, "docker",
+			append([]string{"exec", ctrNameVal}, hc.Cmd...)...).Run()
+		cancel()
+
+		if time.Since(started) < hc.StartPeriod {
+			continue
+		}
+
+		if err != nil {
+			failures++
+			glog.Warningf("Health check failed for container of image "+
+				"%s (%d/%d consecutive failures): %s", img, failures,
+				hc.Retries, err)
+
+			if failures < hc.Retries {
+				continue
+			}
+
+			setHealthState(healthStateUnhealthy)
+			glog.Errorf("Container of image %s is unhealthy after %d "+
+				"consecutive health check failures; stopping it so "+
+				"it is restarted", img, failures)
+			if err := containersvc.Stop(img, ctrNameVal, false); err != nil {
+				glog.Errorf("Failed to stop unhealthy container of "+
+					"image %s: %s", img, err)
+			}
+			return
+		}
+
+		failures = 0
+		setHealthState(healthStateHealthy)
+	}
+}
+
+// ptrBool returns a pointer to v, for Config fields that take *bool.
+func ptrBool(v bool) *bool {
+	return &v
+}
+
+// boolOrDefault returns *b, or def if b is nil.
+func boolOrDefault(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+// configureFromEntry builds a containersvc.Config for a single -config file
+// entry, the same way configure builds one from command-line flags. Fields
+// the entry leaves at their zero value fall back to the command-line flags.
+func configureFromEntry(entry config.Entry) *containersvc.Config {
+	entryPorts := entry.Ports
+	if entryPorts == nil {
+		entryPorts = ports
+	}
+	portMap := make(map[string]string)
+	for _, port := range entryPorts {
+		// port is in the format [host_ip]:host_port:container_port.
+		// host_ip is optional.
+		colSepIdx := strings.LastIndex(port, ":")
+		portMap[port[:colSepIdx]] = port[colSepIdx+1:]
+	}
+
+	entryVols := entry.Volumes
+	if entryVols == nil {
+		entryVols = vols
+	}
+
+	entryEnv := entry.Env
+	if entryEnv == nil {
+		entryEnv = envOpts
+	}
+
+	entryLabels := entry.Labels
+	if entryLabels == nil {
+		entryLabels = labels
+	}
+
+	entryVolOpts := entry.VolumeOpts
+	if entryVolOpts == nil {
+		entryVolOpts = volOpts
+	}
+
+	// Errors are ignored here since validateEntry already rejected a
+	// malformed entry before any entry is started.
+	volMounts, _ := parseVolumes(entryVols)
+	env, _ := parseKeyValueOpts(entryEnv)
+	lbls, _ := parseKeyValueOpts(entryLabels)
+	volNames := make([]string, 0, len(volMounts))
+	for _, mount := range volMounts {
+		volNames = append(volNames, mount.Source)
+	}
+	volOptsMap, _ := parseDriverOpts(entryVolOpts, volNames)
+
+	policy := restartPolicy
+	if entry.RestartPolicy != "" {
+		if err := policy.Set(entry.RestartPolicy); err != nil {
+			glog.Fatalf("Entry %s: invalid restart_policy %q: %s",
+				entry.Image, entry.RestartPolicy, err)
+		}
+	}
+
+	entryHealthCmd := entry.HealthCmd
+	if entryHealthCmd == "" {
+		entryHealthCmd = *healthCmd
+	}
+
+	var hc *containersvc.HealthCheck
+	if entryHealthCmd != "" {
+		interval := time.Duration(entry.HealthInterval)
+		if interval == 0 {
+			interval = *healthInterval
+		}
+		timeout := time.Duration(entry.HealthTimeout)
+		if timeout == 0 {
+			timeout = *healthTimeout
+		}
+		retries := entry.HealthRetries
+		if retries == 0 {
+			retries = *healthRetries
+		}
+		startPeriod := time.Duration(entry.HealthStartPeriod)
+		if startPeriod == 0 {
+			startPeriod = *healthStartPeriod
+		}
+
+		hc = &containersvc.HealthCheck{
+			Cmd:         strings.Fields(entryHealthCmd),
+			Interval:    interval,
+			Timeout:     timeout,
+			Retries:     retries,
+			StartPeriod: startPeriod,
+		}
+	}
+
+	volDriverVal := entry.VolumeDriver
+	if volDriverVal == "" {
+		volDriverVal = *volDriver
+	}
+
+	cfg := &containersvc.Config{
+		CtrName:       entry.ContainerName,
+		PortMap:       portMap,
+		VolumeDriver:  volDriverVal,
+		Volumes:       volMounts,
+		Background:    boolOrDefault(entry.Background, *bckgrnd),
+		RestartPolicy: policy,
+		AutoRemove:    ptrBool(boolOrDefault(entry.AutoRemove, *autoRm)),
+		Log:           boolOrDefault(entry.Log, *log),
+		OpenStdin:     ptrBool(boolOrDefault(entry.OpenStdin, *openStdin)),
+		Tty:           ptrBool(boolOrDefault(entry.Tty, *tty)),
+		OnlyOneContainerInstancePerImage: boolOrDefault(entry.OneInstance,
+			*oneCtr),
+		HealthCheck:      hc,
+		Env:              env,
+		Labels:           lbls,
+		VolumeDriverOpts: volOptsMap,
+	}
+
+	glog.Infof("containersvc config for entry %s: %s", entry.Image,
+		containersvc.PPrint(cfg, true))
+
+	return cfg
+}
+
+// validateEntry checks that entry's ports, volumes, env, labels and
+// volume-opts parse the same way the equivalent command-line flags would,
+// falling back to the command-line flag value wherever entry leaves a field
+// unset, the same way configureFromEntry does. It is called for every entry
+// before any entry is started, so a malformed entry is rejected up front
+// instead of crashing mid-startup or mid-SIGHUP-reload after other entries'
+// containers are already running.
+func validateEntry(entry config.Entry) error {
+	entryPorts := entry.Ports
+	if entryPorts == nil {
+		entryPorts = ports
+	}
+	for _, port := range entryPorts {
+		if !strings.Contains(port, ":") {
+			return fmt.Errorf("entry %s: invalid port %q", entry.Image, port)
+		}
+	}
+
+	entryVols := entry.Volumes
+	if entryVols == nil {
+		entryVols = vols
+	}
+	volMounts, err := parseVolumes(entryVols)
+	if err != nil {
+		return fmt.Errorf("entry %s: %s", entry.Image, err)
+	}
+
+	entryEnv := entry.Env
+	if entryEnv == nil {
+		entryEnv = envOpts
+	}
+	if _, err := parseKeyValueOpts(entryEnv); err != nil {
+		return fmt.Errorf("entry %s: %s", entry.Image, err)
+	}
+
+	entryLabels := entry.Labels
+	if entryLabels == nil {
+		entryLabels = labels
+	}
+	if _, err := parseKeyValueOpts(entryLabels); err != nil {
+		return fmt.Errorf("entry %s: %s", entry.Image, err)
+	}
+
+	entryVolOpts := entry.VolumeOpts
+	if entryVolOpts == nil {
+		entryVolOpts = volOpts
+	}
+	volNames := make([]string, 0, len(volMounts))
+	for _, mount := range volMounts {
+		volNames = append(volNames, mount.Source)
+	}
+	if _, err := parseDriverOpts(entryVolOpts, volNames); err != nil {
+		return fmt.Errorf("entry %s: %s", entry.Image, err)
+	}
+
+	return nil
+}
+
+// runConfiguredLoop is runLoop's -config counterpart: it runs cfg for
+// entry.Image until ctx is cancelled, restarting it with entry-scoped (or,
+// where unset, command-line default) backoff and crash-loop detection.
+// Unlike runLoop, a detected crash loop only tears down this entry (by
+// calling cancel) rather than the whole process, so the other entries
+// supervised by -config keep running.
+func runConfiguredLoop(ctx context.Context, cancel context.CancelFunc,
+	entry config.Entry, cfg *containersvc.Config) {
+
+	minDelay := time.Duration(entry.RestartMinDelay)
+	if minDelay == 0 {
+		minDelay = *restartMinDelay
+	}
+	maxDelay := time.Duration(entry.RestartMaxDelay)
+	if maxDelay == 0 {
+		maxDelay = *restartMaxDelay
+	}
+	multiplier := entry.RestartMultiplier
+	if multiplier == 0 {
+		multiplier = *restartMultiplier
+	}
+	resetAfter := time.Duration(entry.RestartResetAfter)
+	if resetAfter == 0 {
+		resetAfter = *restartResetAfter
+	}
+	crashThreshold := entry.CrashLoopThreshold
+	if crashThreshold == 0 {
+		crashThreshold = *crashLoopThreshold
+	}
+	crashWindow := time.Duration(entry.CrashLoopWindow)
+	if crashWindow == 0 {
+		crashWindow = *crashLoopWindow
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := gracefulStop(entry.Image, entry.ContainerName); err != nil {
+			glog.Errorf("Failed to stop container of image %s: %s",
+				entry.Image, err)
+		}
+	}()
+
+	bo := newRestartBackoff(minDelay, maxDelay, multiplier, resetAfter)
+	var restarts []time.Time
+
+	for ctx.Err() == nil {
+		monitorCtx, monitorCancel := context.WithCancel(ctx)
+		if cfg.HealthCheck != nil {
+			go monitorHealth(monitorCtx, entry.Image, entry.ContainerName,
+				cfg.HealthCheck)
+		}
+
+		startedAt := time.Now()
+		if err := containersvc.Start(entry.ImagePath, entry.Image,
+			cfg); err != nil {
+			glog.Errorf("Failed to start container of image %s: %s",
+				entry.Image, err)
+		}
+		uptime := time.Since(startedAt)
+		monitorCancel()
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		restarts = pruneRestarts(append(restarts, time.Now()), crashWindow)
+		if len(restarts) > crashThreshold {
+			glog.Errorf("Container of image %s restarted %d times within "+
+				"%s; treating this as a crash loop and aborting monitoring "+
+				"of this entry (other supervised entries are unaffected)",
+				entry.Image, len(restarts), crashWindow)
+			cancel()
+			break
+		}
+
+		delay := bo.next(uptime)
+		glog.Infof("Container of image %s exited after %s uptime. "+
+			"Restarting it after %s (restart #%d in the last %s)...",
+			entry.Image, uptime, delay, len(restarts), crashWindow)
+		time.Sleep(delay)
+	}
+	glog.Infof("Stopping monitoring of container of image: %s", entry.Image)
+}
+
+// configLoop tracks one running runConfiguredLoop goroutine so it can be
+// torn down on a SIGHUP reload that removes its entry.
+type configLoop struct {
+	cancel context.CancelFunc
+}
+
+// runMultiService loads path and runs one monitoring loop per entry until
+// ctx is cancelled, then waits for every loop to finish before returning.
+// On SIGHUP, it reloads path and diffs entries by
+// container name, starting newly-added entries and stopping removed ones;
+// entries present in both old and new files keep running unchanged.
+func runMultiService(ctx context.Context, cancel context.CancelFunc,
+	path string) {
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	loops := make(map[string]configLoop)
+
+	start := func(entry config.Entry) {
+		loopCtx, loopCancel := context.WithCancel(ctx)
+		cfg := configureFromEntry(entry)
+
+		mu.Lock()
+		loops[entry.ContainerName] = configLoop{cancel: loopCancel}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runConfiguredLoop(loopCtx, loopCancel, entry, cfg)
+
+			mu.Lock()
+			delete(loops, entry.ContainerName)
+			mu.Unlock()
+		}()
+	}
+
+	ctrNames := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		names := make([]string, 0, len(loops))
+		for name := range loops {
+			names = append(names, name)
+		}
+		return names
+	}
+	regStopSigHandlerMulti(cancel, ctrNames)
+
+	file, err := config.Load(path)
+	if err != nil {
+		glog.Fatalf("Failed to load -config %s: %s", path, err)
+	}
+	for _, entry := range file.Containers {
+		if err := validateEntry(entry); err != nil {
+			glog.Fatalf("Invalid -config %s: %s", path, err)
+		}
+	}
+	for _, entry := range file.Containers {
+		start(entry)
+	}
+
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+
+		case <-hupChan:
+			glog.Infof("Received SIGHUP, reloading -config %s", path)
+
+			newFile, err := config.Load(path)
+			if err != nil {
+				glog.Errorf("Failed to reload -config %s: %s", path, err)
+				continue
+			}
+
+			invalid := false
+			for _, entry := range newFile.Containers {
+				if err := validateEntry(entry); err != nil {
+					glog.Errorf("Failed to reload -config %s: %s", path, err)
+					invalid = true
+				}
+			}
+			if invalid {
+				continue
+			}
+
+			wanted := make(map[string]config.Entry, len(newFile.Containers))
+			for _, entry := range newFile.Containers {
+				wanted[entry.ContainerName] = entry
+			}
+
+			mu.Lock()
+			var toCancel []context.CancelFunc
+			for name, l := range loops {
+				if _, ok := wanted[name]; !ok {
+					toCancel = append(toCancel, l.cancel)
+				}
+			}
+			var toStart []config.Entry
+			for name, entry := range wanted {
+				if _, ok := loops[name]; !ok {
+					toStart = append(toStart, entry)
+				}
+			}
+			mu.Unlock()
+
+			for _, cancel := range toCancel {
+				cancel()
+			}
+			for _, entry := range toStart {
+				glog.Infof("Starting new container entry %s from "+
+					"reloaded config", entry.Image)
+				start(entry)
+			}
+		}
+	}
+}
+
+// regStopSigHandlerMulti registers SIGINT/SIGQUIT/SIGTERM and
+// -forward-signals handling for -config multi-service mode: a stop signal
+// cancels ctx so every loop's own shutdown watcher (see runConfiguredLoop)
+// sends SIGTERM into its container and waits up to -stop-timeout before
+// escalating; a forward signal is instead relayed into every currently
+// running entry's container, via ctrNames.
+func regStopSigHandlerMulti(cancel context.CancelFunc, ctrNames func() []string) {
+	// Errors are ignored here since validateCmdLnFlags already rejected an
+	// unknown -forward-signals entry.
+	forwardSigs, _ := parseForwardSignals(*forwardSignals)
+
+	forward := make(map[os.Signal]bool, len(forwardSigs))
+	for _, sig := range forwardSigs {
+		forward[sig] = true
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	if len(forwardSigs) > 0 {
+		signal.Notify(sigChan, forwardSigs...)
+	}
+
+	go func() {
+		for sig := range sigChan {
+			if forward[sig] {
+				for _, name := range ctrNames() {
+					glog.Infof("Forwarding signal %s into container %s",
+						sig, name)
+					if err := containersvc.Signal(name, sig); err != nil {
+						glog.Errorf("Failed to forward signal %s to "+
+							"container %s: %s", sig, name, err)
+					}
+				}
+				continue
+			}
+
+			glog.Infof("Received stop signal: %s", sig)
+			cancel()
+			return
+		}
+	}()
+}
+
+// handleStatus serves the current monitor status as JSON.
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshotStatus()); err != nil {
+		glog.Errorf("Failed to encode /status response: %s", err)
+	}
+}
+
+// handleRestart stops the running container, without touching stopMonitoring,
+// so runLoop restarts it once on its next iteration.
+func handleRestart(img string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		glog.Infof("Control API: restart requested for container of "+
+			"image %s", img)
+		if err := containersvc.Stop(img, *ctrName, false); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// handleStop follows the same shutdown path as a stop signal: it stops
+// runLoop from restarting the container and stops the container itself.
+func handleStop(cancel context.CancelFunc, img string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		glog.Infof("Control API: stop requested for container of image %s",
+			img)
+		setStopMonitoring()
+		cancel()
+
+		if err := gracefulStop(img, *ctrName); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// handleLogs streams the last N lines of container stdout/stderr collected
+// by containersvc, which only happens when -log=true.
+func handleLogs(w http.ResponseWriter, r *http.Request) {
+	tail := 100
+	if t := r.URL.Query().Get("tail"); t != "" {
+		n, err := strconv.Atoi(t)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid tail parameter", http.StatusBadRequest)
+			return
+		}
+		tail = n
+	}
+
+	if !*log {
+		http.Error(w, "container logging is disabled (-log=false)",
+			http.StatusNotFound)
+		return
+	}
+
+	logs, err := containersvc.TailLogs(*ctrName, tail)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, logs)
+}
+
+// startControlServer serves the -control-addr HTTP status/control API until
+// ctx is cancelled.
+func startControlServer(ctx context.Context, addr string, img string,
+	cancel context.CancelFunc) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", handleStatus)
+	mux.HandleFunc("/restart", handleRestart(img))
+	mux.HandleFunc("/stop", handleStop(cancel, img))
+	mux.HandleFunc("/logs", handleLogs)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(
+			context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			glog.Errorf("Failed to shut down control API server: %s", err)
+		}
+	}()
+
+	glog.Infof("Starting control API on %s", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		glog.Errorf("Control API server failed: %s", err)
+	}
+}
+
+// gracefulStop sends SIGTERM into the container named ctrNameVal and waits
+// -stop-timeout for it to exit on its own before escalating to a hard stop.
+// Used by the control API's /stop endpoint, which follows the same shutdown
+// path as a stop signal but without the interruptible wait stopSigHandler
+// supports, and by runConfiguredLoop's per-entry shutdown watcher.
+func gracefulStop(img, ctrNameVal string) error {
+	glog.Infof("Sending SIGTERM to container of image %s and waiting up "+
+		"to %s for it to exit", img, *stopTimeout)
+	if err := containersvc.Signal(ctrNameVal, syscall.SIGTERM); err != nil {
+		glog.Errorf("Failed to send SIGTERM to container of image %s: %s",
+			img, err)
+	}
+
+	time.Sleep(*stopTimeout)
+
+	return containersvc.Stop(img, ctrNameVal, true)
+}
+
+// namedSignals are the signal names parseForwardSignals recognizes; those in
+// stopSignalNames are still rejected from -forward-signals.
+var namedSignals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// stopSignalNames are the stop signals handled by stopSigHandler; they are
+// rejected from -forward-signals since forwarding one would stop it from
+// ever reaching the stop-signal branch.
+var stopSignalNames = map[string]bool{
+	"INT":  true,
+	"QUIT": true,
+	"TERM": true,
+}
+
+// parseForwardSignals parses the comma-separated -forward-signals flag value
+// into the os.Signal values it names.
+func parseForwardSignals(raw string) ([]os.Signal, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var sigs []os.Signal
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if stopSignalNames[name] {
+			return nil, fmt.Errorf("%s is a stop signal and cannot be "+
+				"forwarded", name)
+		}
+		sig, ok := namedSignals[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown signal %q", name)
+		}
+		sigs = append(sigs, sig)
+	}
+
+	return sigs, nil
+}
+
+// stopSigHandler is the signal handler for the container service's stop
+// signals (SIGINT, SIGQUIT, SIGTERM) and the signals configured via
+// -forward-signals.
+// On a forward signal, it just relays it into the container via
+// containersvc.Signal. On a stop signal, it sends SIGTERM into the
+// container and waits up to -stop-timeout for it to exit on its own before
+// escalating to a hard stop. A forward signal received during that wait is
+// relayed the same as always and does not affect the wait; a second stop
+// signal received during that wait escalates to a hard stop immediately.
+// It runs as a goroutine and is waiting on the sigChan channel for signals.
+func stopSigHandler(sigChan chan os.Signal, cancel context.CancelFunc,
+	img string, forward map[os.Signal]bool) {
+
 	if glog.V(2) {
 		glog.Info("Waiting for stop signals...")
 	}
 
-	sig := <-sigChan
+	for sig := range sigChan {
+		if forward[sig] {
+			glog.Infof("Forwarding signal %s into container of image %s",
+				sig, img)
+			if err := containersvc.Signal(*ctrName, sig); err != nil {
+				glog.Errorf("Failed to forward signal %s to container "+
+					"of image %s: %s", sig, img, err)
+			}
+			continue
+		}
+
+		glog.Infof("Received stop signal: %s", sig)
 
-	glog.Infof("Received stop signal: %s", sig)
+		setStopMonitoring()
+		cancel()
 
-	stopMonitoring = true
+		glog.Infof("Sending SIGTERM to container of image %s and waiting "+
+			"up to %s for it to exit", img, *stopTimeout)
+		if err := containersvc.Signal(*ctrName, syscall.SIGTERM); err != nil {
+			glog.Errorf("Failed to send SIGTERM to container of image "+
+				"%s: %s", img, err)
+		}
 
-	if err := containersvc.Stop(img, *ctrName, false); err != nil {
-		glog.Errorf("Failed to stop container of image %s", img)
+		timeout := time.After(*stopTimeout)
+	waitForExit:
+		for {
+			select {
+			case <-timeout:
+				glog.Infof("Container of image %s did not exit within %s; "+
+					"escalating to a hard stop", img, *stopTimeout)
+				break waitForExit
+			case second := <-sigChan:
+				if forward[second] {
+					glog.Infof("Forwarding signal %s into container of "+
+						"image %s", second, img)
+					if err := containersvc.Signal(*ctrName, second); err != nil {
+						glog.Errorf("Failed to forward signal %s to "+
+							"container of image %s: %s", second, img, err)
+					}
+					continue
+				}
+
+				glog.Infof("Received second stop signal %s while waiting; "+
+					"escalating to a hard stop immediately", second)
+				break waitForExit
+			}
+		}
+
+		if err := containersvc.Stop(img, *ctrName, true); err != nil {
+			glog.Errorf("Failed to stop container of image %s", img)
+		}
+		return
 	}
 }
 
-// regStopSigHandler registers the signal handler for signals that could be used
-// to stop the container service: SIGINT, SIGQUIT amd SIGTERM and starts the
-// signal handler as a go routine.
-func regStopSigHandler(img string) {
+// regStopSigHandler registers the signal handler for the stop signals
+// (SIGINT, SIGQUIT, SIGTERM) and the -forward-signals signals, and starts the
+// signal handler as a go routine. cancel is invoked to tear down any
+// context-scoped goroutines, such as the health check monitor.
+func regStopSigHandler(cancel context.CancelFunc, img string) {
 	if glog.V(2) {
 		glog.Info("Registering signal handlers for stop signals: " +
 			"SIGINT, SIGQUIT and SIGTERM")
 	}
 
+	// Errors are ignored here since validateCmdLnFlags already rejected an
+	// unknown -forward-signals entry.
+	forwardSigs, _ := parseForwardSignals(*forwardSignals)
+
+	forward := make(map[os.Signal]bool, len(forwardSigs))
+	for _, sig := range forwardSigs {
+		forward[sig] = true
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
-	go stopSigHandler(sigChan, img)
+	// signal.Notify with no signals means "relay everything", so only call
+	// it for forwardSigs when there actually are some.
+	if len(forwardSigs) > 0 {
+		signal.Notify(sigChan, forwardSigs...)
+	}
+	go stopSigHandler(sigChan, cancel, img, forward)
 }
 
 // validateCmdLnFlag validates command-line flags and is called for each flag
@@ -177,12 +1328,9 @@ func validateCmdLnFlag(fl *flag.Flag) {
 			}
 		}
 	} else if fl.Name == "volume" {
-		for _, vol := range vols {
-			if !strings.Contains(vol, ":") {
-				glog.Errorf("%s: invalid format: %s: %s", vol,
-					fl.Name, fl.Usage)
-				os.Exit(255)
-			}
+		if _, err := parseVolumes(vols); err != nil {
+			glog.Errorf("%s: %s: %s", fl.Name, err, fl.Usage)
+			os.Exit(255)
 		}
 	} else if fl.Name == "restart-policy" {
 		if !(restartPolicy == containersvc.No ||
@@ -194,6 +1342,56 @@ func validateCmdLnFlag(fl *flag.Flag) {
 				"%s", restartPolicy, fl.Name, fl.Usage)
 			os.Exit(255)
 		}
+	} else if fl.Name == "restart-max-delay" || fl.Name == "restart-min-delay" {
+		if *restartMaxDelay < *restartMinDelay {
+			glog.Errorf("%s: -restart-max-delay must not be smaller "+
+				"than -restart-min-delay: %s", fl.Name, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "health-interval" {
+		if *healthInterval <= 0 {
+			glog.Errorf("%s: -health-interval must be > 0: %s",
+				fl.Name, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "health-timeout" {
+		if *healthTimeout <= 0 {
+			glog.Errorf("%s: -health-timeout must be > 0: %s",
+				fl.Name, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "restart-multiplier" {
+		if *restartMultiplier < 1 {
+			glog.Errorf("%s: -restart-multiplier must be >= 1: %s",
+				fl.Name, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "crash-loop-threshold" {
+		if *crashLoopThreshold < 1 {
+			glog.Errorf("%s: -crash-loop-threshold must be >= 1: %s",
+				fl.Name, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "env" {
+		if _, err := parseKeyValueOpts(envOpts); err != nil {
+			glog.Errorf("%s: %s: %s", fl.Name, err, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "label" {
+		if _, err := parseKeyValueOpts(labels); err != nil {
+			glog.Errorf("%s: %s: %s", fl.Name, err, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "volume-opt" {
+		if _, err := parseDriverOpts(volOpts, volumeNames()); err != nil {
+			glog.Errorf("%s: %s: %s", fl.Name, err, fl.Usage)
+			os.Exit(255)
+		}
+	} else if fl.Name == "forward-signals" {
+		if _, err := parseForwardSignals(*forwardSignals); err != nil {
+			glog.Errorf("%s: %s: %s", fl.Name, err, fl.Usage)
+			os.Exit(255)
+		}
 	}
 
 }
@@ -273,7 +1471,16 @@ func main() {
 	flag.Var(&ports, "port", "Port mapping(s) between host and container "+
 		"in the format: [host_ip:]host_port:container_port")
 	flag.Var(&vols, "volume", "Volumes to be mounted in the container in "+
-		"the format: volume_name/host_path:container_path")
+		"the format: volume_name/host_path:container_path[:option[,option"+
+		"...]]. Recognized options: z, Z (SELinux relabeling), ro, rw, "+
+		"rshared, rslave, rprivate (propagation/mode)")
+	flag.Var(&envOpts, "env", "Environment variable(s) to set in the "+
+		"container, in KEY=VALUE format. Can be repeated.")
+	flag.Var(&labels, "label", "Label(s) to set on the container, in "+
+		"KEY=VALUE format. Can be repeated.")
+	flag.Var(&volOpts, "volume-opt", "Volume driver option(s), in "+
+		"NAME.KEY=VALUE format where NAME matches a -volume entry. Can "+
+		"be repeated.")
 	flag.Var(&restartPolicy, "restart-policy", fmt.Sprintf("Restart policy "+
 		"to be used for the container. Valid restart policies: %s, %s,"+
 		" %s, %s", containersvc.No, containersvc.OnFailure,
@@ -282,11 +1489,25 @@ func main() {
 	flag.Parse()
 	flag.Set("logtostderr", "true")
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *configPath != "" {
+		validateCmdLnFlags()
+		runMultiService(ctx, cancel, *configPath)
+		glog.Flush()
+		return
+	}
+
 	imgPath, img := parseCmdLnArgs(flag.Args())
 
-	regStopSigHandler(img)
+	regStopSigHandler(cancel, img)
+
+	if *controlAddr != "" {
+		go startControlServer(ctx, *controlAddr, img, cancel)
+	}
 
-	runLoop(imgPath, img)
+	runLoop(ctx, imgPath, img)
 
 	glog.Flush()
 }